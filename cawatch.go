@@ -0,0 +1,120 @@
+package tlsping
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// defaultCAReloadInterval is the polling interval used by NewCAWatcher
+// when the caller passes a zero interval.
+const defaultCAReloadInterval = 30 * time.Second
+
+// CAWatcher periodically re-reads a CA bundle file from disk and keeps
+// an atomically-swapped *x509.CertPool up to date, so that a CA bundle
+// rotated on disk can be picked up by a long-running PingStream without
+// a restart. In-flight dials keep using the pool they were handed; only
+// dials started after a reload see the new one.
+type CAWatcher struct {
+	path     string
+	interval time.Duration
+	hash     [sha256.Size]byte
+	pool     atomic.Pointer[x509.CertPool]
+	events   chan CAReloadEvent
+}
+
+// CAReloadEvent reports the outcome of a single reload attempt. Err is
+// nil when the bundle content changed and was loaded successfully; it is
+// set when reading or parsing the file failed, in which case the
+// watcher keeps serving its last good pool.
+type CAReloadEvent struct {
+	Err error
+}
+
+// NewCAWatcher loads the CA bundle at path and returns a CAWatcher
+// seeded with the resulting pool. interval is how often the file is
+// checked for changes; a zero interval defaults to
+// defaultCAReloadInterval. Call Run to start watching for changes.
+func NewCAWatcher(path string, interval time.Duration) (*CAWatcher, error) {
+	if interval <= 0 {
+		interval = defaultCAReloadInterval
+	}
+	pool, hash, err := loadCAPool(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &CAWatcher{
+		path:     path,
+		interval: interval,
+		hash:     hash,
+		events:   make(chan CAReloadEvent, 1),
+	}
+	w.pool.Store(pool)
+	return w, nil
+}
+
+// Pool returns the most recently loaded CA pool. It is safe to call
+// concurrently with Run.
+func (w *CAWatcher) Pool() *x509.CertPool {
+	return w.pool.Load()
+}
+
+// Events returns the channel on which reload outcomes are reported. It
+// is unbuffered beyond a single pending event, so a slow consumer only
+// misses the logging of a reload, never the reload itself.
+func (w *CAWatcher) Events() <-chan CAReloadEvent {
+	return w.events
+}
+
+// Run polls the CA bundle for changes until done is closed. Whenever the
+// file's content changes, it rebuilds the pool and swaps it in; reload
+// errors are reported on Events and do not stop the watcher, which keeps
+// retrying on the next tick.
+func (w *CAWatcher) Run(done <-chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			pool, hash, err := loadCAPool(w.path)
+			if err != nil {
+				w.notify(CAReloadEvent{Err: err})
+				continue
+			}
+			if hash == w.hash {
+				continue
+			}
+			w.hash = hash
+			w.pool.Store(pool)
+			w.notify(CAReloadEvent{})
+		case <-done:
+			return
+		}
+	}
+}
+
+// notify delivers ev on events without blocking if nobody is listening.
+func (w *CAWatcher) notify(ev CAReloadEvent) {
+	select {
+	case w.events <- ev:
+	default:
+	}
+}
+
+// loadCAPool reads and parses the PEM-encoded CA bundle at path,
+// returning the resulting pool along with a content hash used to detect
+// changes on the next poll.
+func loadCAPool(path string) (*x509.CertPool, [sha256.Size]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, [sha256.Size]byte{}, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, [sha256.Size]byte{}, fmt.Errorf("no valid certificates found in '%s'", path)
+	}
+	return pool, sha256.Sum256(data), nil
+}
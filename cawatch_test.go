@@ -0,0 +1,89 @@
+package tlsping
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testCert1 = `-----BEGIN CERTIFICATE-----
+MIIBejCCAR+gAwIBAgIUZ9J4WLpEhsqTlZAgg+27Mwnar/4wCgYIKoZIzj0EAwIw
+EjEQMA4GA1UECgwHQWNtZSBDbzAeFw0yNjA3MjYwODM1NDJaFw0zNjA3MjMwODM1
+NDJaMBIxEDAOBgNVBAoMB0FjbWUgQ28wWTATBgcqhkjOPQIBBggqhkjOPQMBBwNC
+AARmodctA8t3zzUyH9VFbG24WpiaY6JSyPeK3UTVNH4juE4dJHSJnIi/brgsTDlI
+Bm0cI8DkhU3uMh6KsT4EQ0cRo1MwUTAdBgNVHQ4EFgQUylNTdNVwz9Y0eXxVgLBx
+ejgq/OswHwYDVR0jBBgwFoAUylNTdNVwz9Y0eXxVgLBxejgq/OswDwYDVR0TAQH/
+BAUwAwEB/zAKBggqhkjOPQQDAgNJADBGAiEAneIs0HO7cYIPWWpPpO5+bcQJaj2U
+IA5GfZ4ypCzesqsCIQDNnQcCNplN/ryp0iXrlisWQJzsVO8luImRDIEVmYDAfw==
+-----END CERTIFICATE-----
+`
+
+func TestCAWatcherDetectsContentChange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(path, []byte(testCert1), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	_, hash1, err := loadCAPool(path)
+	if err != nil {
+		t.Fatalf("loadCAPool: %v", err)
+	}
+
+	// Re-reading the same content must produce the same hash.
+	_, hash2, err := loadCAPool(path)
+	if err != nil {
+		t.Fatalf("loadCAPool: %v", err)
+	}
+	if hash1 != hash2 {
+		t.Fatalf("hash changed across reads of identical content")
+	}
+
+	// Appending a trailing newline changes the content, and the hash.
+	if err := os.WriteFile(path, []byte(testCert1+"\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	_, hash3, err := loadCAPool(path)
+	if err != nil {
+		t.Fatalf("loadCAPool: %v", err)
+	}
+	if hash1 == hash3 {
+		t.Fatalf("hash did not change after content was modified")
+	}
+}
+
+func TestCAWatcherRunReloadsOnChange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(path, []byte(testCert1), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	w, err := NewCAWatcher(path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewCAWatcher: %v", err)
+	}
+	initialPool := w.Pool()
+
+	done := make(chan struct{})
+	go w.Run(done)
+	defer close(done)
+
+	if err := os.WriteFile(path, []byte(testCert1+"\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	select {
+	case ev := <-w.Events():
+		if ev.Err != nil {
+			t.Fatalf("unexpected reload error: %v", ev.Err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload event")
+	}
+
+	if w.Pool() == initialPool {
+		t.Fatal("pool pointer was not swapped after a reload")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/airnandez/tlsping"
+)
+
+// headerFlags accumulates repeated -H header:value flags into an
+// http.Header, implementing flag.Value.
+type headerFlags struct {
+	header http.Header
+}
+
+func (h *headerFlags) String() string {
+	return ""
+}
+
+func (h *headerFlags) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("invalid header '%s', expected 'name:value'", value)
+	}
+	if h.header == nil {
+		h.header = make(http.Header)
+	}
+	h.header.Add(strings.TrimSpace(name), strings.TrimSpace(val))
+	return nil
+}
+
+// buildHTTPProbe assembles a tlsping.HTTPProbe from the -http, -X, -H and
+// -path flags. It returns a zero-value, disabled probe when httpProbe is
+// false.
+func buildHTTPProbe(httpProbe bool, method, path string, headers *headerFlags) tlsping.HTTPProbe {
+	if !httpProbe {
+		return tlsping.HTTPProbe{}
+	}
+	return tlsping.HTTPProbe{
+		Enabled: true,
+		Method:  method,
+		Path:    path,
+		Header:  headers.header,
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestHeaderFlagsSet(t *testing.T) {
+	var h headerFlags
+	if err := h.Set("Accept: text/plain"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := h.Set("Accept: text/html"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	got := h.header.Values("Accept")
+	want := []string{"text/plain", "text/html"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("header[Accept] = %v, want %v", got, want)
+	}
+
+	if err := h.Set("no-colon-here"); err == nil {
+		t.Error("Set with no colon should return an error")
+	}
+}
+
+func TestBuildHTTPProbeDisabled(t *testing.T) {
+	var h headerFlags
+	probe := buildHTTPProbe(false, "GET", "/", &h)
+	if probe.Enabled {
+		t.Error("buildHTTPProbe(false, ...) should return a disabled probe")
+	}
+}
+
+func TestBuildHTTPProbeEnabled(t *testing.T) {
+	var h headerFlags
+	if err := h.Set("X-Test: 1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	probe := buildHTTPProbe(true, "POST", "/ping", &h)
+	if !probe.Enabled || probe.Method != "POST" || probe.Path != "/ping" {
+		t.Errorf("buildHTTPProbe(true, POST, /ping, ...) = %+v", probe)
+	}
+	if probe.Header.Get("X-Test") != "1" {
+		t.Errorf("probe.Header[X-Test] = %q, want %q", probe.Header.Get("X-Test"), "1")
+	}
+}
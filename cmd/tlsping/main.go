@@ -1,11 +1,13 @@
 package main
 
 import (
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 
 	"github.com/airnandez/tlsping"
@@ -18,14 +20,42 @@ func main() {
 	}
 	tcpOnly := fset.Bool("tcponly", false, "")
 	count := fset.Int("c", defaultIterations, "")
+	interval := fset.Duration("i", 0, "")
+	phases := fset.Bool("phases", false, "")
+	resume := fset.String("resume", "off", "")
 	jsonOutput := fset.Bool("json", false, "")
 	insecure := fset.Bool("insecure", false, "")
 	ca := fset.String("ca", "", "")
+	caReload := fset.Duration("ca-reload", 0, "")
+	clientCert := fset.String("cert", "", "")
+	clientKey := fset.String("key", "", "")
+	tlsMin := fset.String("tls-min", "", "")
+	tlsMax := fset.String("tls-max", "", "")
+	cipherSuites := fset.String("cipher", "", "")
+	alpn := fset.String("alpn", "", "")
+	serverNameOverride := fset.String("servername", "", "")
+	httpProbe := fset.Bool("http", false, "")
+	httpMethod := fset.String("X", "GET", "")
+	httpPath := fset.String("path", "/", "")
+	http1 := fset.Bool("http1.1", false, "")
+	var httpHeaders headerFlags
+	fset.Var(&httpHeaders, "H", "")
 	version := fset.Bool("version", false, "")
 	help := fset.Bool("help", false, "")
 	ip := fset.String("ip", "", "")
 	fset.Parse(os.Args[1:])
 
+	countSet := false
+	alpnSet := false
+	fset.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "c":
+			countSet = true
+		case "alpn":
+			alpnSet = true
+		}
+	})
+
 	if *version {
 		printVersion(os.Stderr)
 		os.Exit(0)
@@ -41,26 +71,126 @@ func main() {
 		os.Exit(1)
 	}
 	serverAddr := args[0]
-	if *count <= 0 {
+	if !countSet {
+		*count = 0
+	}
+	if *interval <= 0 && *count <= 0 {
 		*count = 1
 	}
-	if *count > maxCount {
+	if countSet && *count > maxCount {
 		errlog.Printf("number of allowed connections cannot exceed %d\n", maxCount)
 		printUsage(os.Stderr, usageShort)
 		os.Exit(1)
 	}
+	if *phases && *interval > 0 {
+		errlog.Printf("-phases is not supported together with -i: phase timing is not available in streaming mode\n")
+		printUsage(os.Stderr, usageShort)
+		os.Exit(1)
+	}
 	caCerts, err := loadCaCerts(*ca)
 	if err != nil {
 		errlog.Printf("%s\n", err)
 		printUsage(os.Stderr, usageShort)
 		os.Exit(1)
 	}
+	var caWatcher *tlsping.CAWatcher
+	if *caReload > 0 {
+		if *ca == "" {
+			errlog.Printf("-ca-reload requires -ca\n")
+			printUsage(os.Stderr, usageShort)
+			os.Exit(1)
+		}
+		if *interval <= 0 {
+			errlog.Printf("-ca-reload requires -i: the CA bundle is only watched in streaming mode\n")
+			printUsage(os.Stderr, usageShort)
+			os.Exit(1)
+		}
+		caWatcher, err = tlsping.NewCAWatcher(*ca, *caReload)
+		if err != nil {
+			errlog.Printf("%s\n", err)
+			printUsage(os.Stderr, usageShort)
+			os.Exit(1)
+		}
+	}
+	resumption, err := parseResumption(*resume)
+	if err != nil {
+		errlog.Printf("%s\n", err)
+		printUsage(os.Stderr, usageShort)
+		os.Exit(1)
+	}
+	if resumption == tlsping.ResumptionCompare && *interval > 0 {
+		errlog.Printf("-resume=compare is not supported together with -i\n")
+		printUsage(os.Stderr, usageShort)
+		os.Exit(1)
+	}
+	if resumption == tlsping.ResumptionCompare && *phases {
+		errlog.Printf("-resume=compare is not supported together with -phases\n")
+		printUsage(os.Stderr, usageShort)
+		os.Exit(1)
+	}
+	if resumption == tlsping.ResumptionCompare && *httpProbe {
+		errlog.Printf("-resume=compare is not supported together with -http\n")
+		printUsage(os.Stderr, usageShort)
+		os.Exit(1)
+	}
+	clientCerts, err := loadClientCert(*clientCert, *clientKey)
+	if err != nil {
+		errlog.Printf("%s\n", err)
+		printUsage(os.Stderr, usageShort)
+		os.Exit(1)
+	}
+	minVersion, err := parseTLSVersion(*tlsMin)
+	if err != nil {
+		errlog.Printf("%s\n", err)
+		printUsage(os.Stderr, usageShort)
+		os.Exit(1)
+	}
+	maxVersion, err := parseTLSVersion(*tlsMax)
+	if err != nil {
+		errlog.Printf("%s\n", err)
+		printUsage(os.Stderr, usageShort)
+		os.Exit(1)
+	}
+	suites, err := parseCipherSuites(*cipherSuites)
+	if err != nil {
+		errlog.Printf("%s\n", err)
+		printUsage(os.Stderr, usageShort)
+		os.Exit(1)
+	}
+	// The HTTP probe only speaks http/1.1 framing (no h2 support), so when
+	// it is enabled the negotiated ALPN protocol defaults to "http/1.1"
+	// unless the caller explicitly overrides it with -alpn; -http1.1
+	// makes that default explicit. An explicit -alpn that still
+	// negotiates "h2" is rejected at dial time by the probe itself.
+	nextProtos := parseALPN(*alpn)
+	if *httpProbe && (*http1 || !alpnSet) {
+		nextProtos = []string{"http/1.1"}
+	}
 	config := tlsping.Config{
 		Count:              *count,
+		Interval:           *interval,
 		AvoidTLSHandshake:  *tcpOnly,
 		InsecureSkipVerify: *insecure,
 		RootCAs:            caCerts,
-		Ip:                 ip,
+		MeasurePhases:      *phases,
+		SessionResumption:  resumption,
+		Certificates:       clientCerts,
+		MinVersion:         minVersion,
+		MaxVersion:         maxVersion,
+		CipherSuites:       suites,
+		NextProtos:         nextProtos,
+		ServerNameOverride: *serverNameOverride,
+		HTTPProbe:          buildHTTPProbe(*httpProbe, *httpMethod, *httpPath, &httpHeaders),
+		CAWatcher:          caWatcher,
+		Ip:                 *ip,
+	}
+	if *interval > 0 {
+		runStream(serverAddr, &config, *tcpOnly, *jsonOutput)
+		os.Exit(0)
+	}
+	if resumption == tlsping.ResumptionCompare {
+		runCompareResumption(serverAddr, &config, *jsonOutput)
+		os.Exit(0)
 	}
 	result, err := tlsping.Ping(serverAddr, &config)
 	if err != nil {
@@ -74,6 +204,21 @@ func main() {
 	if !*jsonOutput {
 		outlog.Printf("%s connection to %s (%s) (%d connections)\n", s, serverAddr, result.IPAddr, *count)
 		outlog.Printf("min/avg/max/stddev = %s/%s/%s/%s\n", result.MinStr(), result.AvgStr(), result.MaxStr(), result.StdStr())
+		if *phases && result.Phases != nil {
+			printPhase(outlog, "dns      ", result.Phases.DNS)
+			printPhase(outlog, "tcp      ", result.Phases.TCP)
+			printPhase(outlog, "handshake", result.Phases.Handshake)
+		}
+		if resumption == tlsping.ResumptionReuse {
+			outlog.Printf("resumed = %t\n", result.Resumed)
+		}
+		if !*tcpOnly {
+			outlog.Printf("version/cipher/alpn = %s/%s/%s\n", result.NegotiatedVersion, result.NegotiatedCipher, result.NegotiatedProto)
+		}
+		if *httpProbe && result.TTFB != nil {
+			outlog.Printf("http status = %d\n", result.HTTPStatus)
+			printPhase(outlog, "ttfb     ", *result.TTFB)
+		}
 		os.Exit(0)
 	}
 
@@ -89,6 +234,27 @@ func main() {
 		Avg:        result.Avg,
 		Std:        result.Std,
 	}
+	if *phases && result.Phases != nil {
+		jsonRes.Phases = &phasesJSON{
+			DNS:       phaseStatsJSON(result.Phases.DNS),
+			TCP:       phaseStatsJSON(result.Phases.TCP),
+			Handshake: phaseStatsJSON(result.Phases.Handshake),
+		}
+	}
+	if resumption == tlsping.ResumptionReuse {
+		jsonRes.Resumed = result.Resumed
+	}
+	if !*tcpOnly {
+		jsonRes.Version = result.NegotiatedVersion
+		jsonRes.Cipher = result.NegotiatedCipher
+		jsonRes.ALPN = result.NegotiatedProto
+	}
+	if *httpProbe && result.TTFB != nil {
+		jsonRes.HTTPStatus = result.HTTPStatus
+		jsonRes.TTFB = &phaseStatsJSON{
+			Min: result.TTFB.Min, Avg: result.TTFB.Avg, Max: result.TTFB.Max, Std: result.TTFB.Std,
+		}
+	}
 	if err != nil {
 		jsonRes.Error = fmt.Sprintf("%s", err)
 	}
@@ -102,16 +268,46 @@ func main() {
 }
 
 type JsonResult struct {
-	Host       string  `json:"host"`
-	IPAddr     string  `json:"ip"`
-	ServerAddr string  `json:"address"`
-	Connection string  `json:"connection"`
-	Count      int     `json:"count"`
-	Min        float64 `json:"min"`
-	Max        float64 `json:"max"`
-	Avg        float64 `json:"average"`
-	Std        float64 `json:"stddev"`
-	Error      string  `json:"error"`
+	Host             string          `json:"host"`
+	IPAddr           string          `json:"ip"`
+	ServerAddr       string          `json:"address"`
+	Connection       string          `json:"connection"`
+	Count            int             `json:"count"`
+	Min              float64         `json:"min"`
+	Max              float64         `json:"max"`
+	Avg              float64         `json:"average"`
+	Std              float64         `json:"stddev"`
+	Phases           *phasesJSON     `json:"phases,omitempty"`
+	Version          string          `json:"version,omitempty"`
+	Cipher           string          `json:"cipher,omitempty"`
+	ALPN             string          `json:"alpn,omitempty"`
+	Resumed          bool            `json:"resumed,omitempty"`
+	Full             *phaseStatsJSON `json:"full,omitempty"`
+	ResumedHandshake *phaseStatsJSON `json:"resumedHandshake,omitempty"`
+	Speedup          float64         `json:"speedup,omitempty"`
+	HTTPStatus       int             `json:"httpStatus,omitempty"`
+	TTFB             *phaseStatsJSON `json:"ttfb,omitempty"`
+	Error            string          `json:"error"`
+}
+
+// phaseStatsJSON mirrors tlsping.PhaseStats for JSON output.
+type phaseStatsJSON struct {
+	Min float64 `json:"min"`
+	Avg float64 `json:"average"`
+	Max float64 `json:"max"`
+	Std float64 `json:"stddev"`
+}
+
+// phasesJSON mirrors tlsping.ConnectionPhases for JSON output.
+type phasesJSON struct {
+	DNS       phaseStatsJSON `json:"dns"`
+	TCP       phaseStatsJSON `json:"tcp"`
+	Handshake phaseStatsJSON `json:"handshake"`
+}
+
+// printPhase prints one line of the per-phase breakdown.
+func printPhase(l *log.Logger, label string, s tlsping.PhaseStats) {
+	l.Printf("%s min/avg/max/stddev = %.3fs/%.3fs/%.3fs/%.3fs\n", label, s.Min, s.Avg, s.Max, s.Std)
 }
 
 func loadCaCerts(path string) (*x509.CertPool, error) {
@@ -128,3 +324,20 @@ func loadCaCerts(path string) (*x509.CertPool, error) {
 	}
 	return pool, nil
 }
+
+// loadClientCert loads a client certificate and private key, used for
+// mutual TLS authentication. If both certFile and keyFile are empty, no
+// certificate is loaded and this is a no-op.
+func loadClientCert(certFile, keyFile string) ([]tls.Certificate, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both -cert and -key must be provided")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading client certificate from '%s' and '%s': %s", certFile, keyFile, err)
+	}
+	return []tls.Certificate{cert}, nil
+}
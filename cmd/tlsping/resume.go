@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/airnandez/tlsping"
+)
+
+// parseResumption converts the value of the -resume flag into a
+// tlsping.SessionResumption.
+func parseResumption(s string) (tlsping.SessionResumption, error) {
+	switch s {
+	case "", "off":
+		return tlsping.ResumptionOff, nil
+	case "reuse":
+		return tlsping.ResumptionReuse, nil
+	case "compare":
+		return tlsping.ResumptionCompare, nil
+	default:
+		return tlsping.ResumptionOff, fmt.Errorf("invalid value for -resume: '%s'", s)
+	}
+}
+
+// runCompareResumption runs config.Count full handshakes followed by
+// config.Count resumed handshakes, and prints the two summaries plus the
+// mean speedup, in JSON when jsonOutput is set.
+func runCompareResumption(serverAddr string, config *tlsping.Config, jsonOutput bool) {
+	full, resumed, speedup, err := tlsping.CompareResumption(serverAddr, config)
+	if err != nil {
+		errlog.Printf("error connecting to '%s': %s\n", serverAddr, err)
+		os.Exit(1)
+	}
+	if !jsonOutput {
+		outlog.Printf("TLS connection to %s (%s) (%d connections)\n", serverAddr, full.IPAddr, config.Count)
+		outlog.Printf("full handshake    min/avg/max/stddev = %s/%s/%s/%s\n", full.MinStr(), full.AvgStr(), full.MaxStr(), full.StdStr())
+		outlog.Printf("resumed handshake min/avg/max/stddev = %s/%s/%s/%s\n", resumed.MinStr(), resumed.AvgStr(), resumed.MaxStr(), resumed.StdStr())
+		outlog.Printf("speedup = %.2fx\n", speedup)
+		return
+	}
+
+	jsonRes := JsonResult{
+		Host:       full.Host,
+		IPAddr:     full.IPAddr,
+		ServerAddr: serverAddr,
+		Connection: "TLS",
+		Count:      config.Count,
+		Full: &phaseStatsJSON{
+			Min: full.Min, Avg: full.Avg, Max: full.Max, Std: full.Std,
+		},
+		ResumedHandshake: &phaseStatsJSON{
+			Min: resumed.Min, Avg: resumed.Avg, Max: resumed.Max, Std: resumed.Std,
+		},
+		Speedup: speedup,
+	}
+	b, err := json.Marshal(jsonRes)
+	if err != nil {
+		errlog.Printf("error producing JSON: %s\n", err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(b)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/airnandez/tlsping"
+)
+
+// runStream drives the continuous ping mode: it opens one connection
+// every config.Interval, printing one line per attempt, until config.Count
+// attempts have been made (when set) or the process receives SIGINT or
+// SIGTERM. On exit it prints the summary statistics, in JSON when
+// jsonOutput is set.
+func runStream(serverAddr string, config *tlsping.Config, tcpOnly bool, jsonOutput bool) {
+	samples, stop, err := tlsping.PingStream(serverAddr, config)
+	if err != nil {
+		errlog.Printf("error connecting to '%s': %s\n", serverAddr, err)
+		os.Exit(1)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		stop()
+	}()
+
+	if config.CAWatcher != nil {
+		go func() {
+			for ev := range config.CAWatcher.Events() {
+				if ev.Err != nil {
+					errlog.Printf("error reloading CA bundle: %s\n", ev.Err)
+					continue
+				}
+				outlog.Printf("CA bundle reloaded\n")
+			}
+		}()
+	}
+
+	s := "TLS"
+	if tcpOnly {
+		s = "TCP"
+	}
+	durations := make([]float64, 0)
+	ttfbDurations := make([]float64, 0)
+	for sample := range samples {
+		if sample.Err != nil {
+			errlog.Printf("seq=%d error: %s\n", sample.Seq, sample.Err)
+			continue
+		}
+		durations = append(durations, sample.Elapsed)
+		if config.HTTPProbe.Enabled {
+			ttfbDurations = append(ttfbDurations, sample.TTFB)
+		}
+		if !jsonOutput {
+			switch {
+			case tcpOnly:
+				outlog.Printf("seq=%d ip=%s time=%.3fs\n", sample.Seq, sample.IPAddr, sample.Elapsed)
+			case config.HTTPProbe.Enabled:
+				outlog.Printf("seq=%d ip=%s time=%.3fs version=%s cipher=%s resumed=%t status=%d ttfb=%.3fs\n",
+					sample.Seq, sample.IPAddr, sample.Elapsed, sample.TLSVersion, sample.CipherSuite, sample.Resumed, sample.HTTPStatus, sample.TTFB)
+			default:
+				outlog.Printf("seq=%d ip=%s time=%.3fs version=%s cipher=%s resumed=%t\n",
+					sample.Seq, sample.IPAddr, sample.Elapsed, sample.TLSVersion, sample.CipherSuite, sample.Resumed)
+			}
+		}
+	}
+
+	summary := tlsping.NewSummary(durations)
+	var ttfbSummary tlsping.PingResult
+	if config.HTTPProbe.Enabled {
+		ttfbSummary = tlsping.NewSummary(ttfbDurations)
+	}
+	if !jsonOutput {
+		outlog.Printf("%s connection to %s (%d connections)\n", s, serverAddr, len(durations))
+		outlog.Printf("min/avg/max/stddev = %s/%s/%s/%s\n", summary.MinStr(), summary.AvgStr(), summary.MaxStr(), summary.StdStr())
+		if config.HTTPProbe.Enabled {
+			outlog.Printf("ttfb      min/avg/max/stddev = %s/%s/%s/%s\n", ttfbSummary.MinStr(), ttfbSummary.AvgStr(), ttfbSummary.MaxStr(), ttfbSummary.StdStr())
+		}
+		return
+	}
+
+	jsonRes := JsonResult{
+		ServerAddr: serverAddr,
+		Connection: s,
+		Count:      len(durations),
+		Min:        summary.Min,
+		Max:        summary.Max,
+		Avg:        summary.Avg,
+		Std:        summary.Std,
+	}
+	if config.HTTPProbe.Enabled {
+		jsonRes.TTFB = &phaseStatsJSON{
+			Min: ttfbSummary.Min, Avg: ttfbSummary.Avg, Max: ttfbSummary.Max, Std: ttfbSummary.Std,
+		}
+	}
+	b, err := json.Marshal(jsonRes)
+	if err != nil {
+		errlog.Printf("error producing JSON: %s\n", err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(b)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// parseTLSVersion converts a version string such as "1.2" or "1.3" into
+// the corresponding tls.VersionTLSxx constant. An empty string returns 0,
+// letting crypto/tls pick its default.
+func parseTLSVersion(s string) (uint16, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("invalid TLS version '%s'", s)
+	}
+}
+
+// parseCipherSuites converts a comma-separated list of cipher suite
+// names, e.g. "TLS_RSA_WITH_AES_128_CBC_SHA,TLS_RSA_WITH_AES_256_CBC_SHA",
+// into the corresponding cipher suite IDs. An empty string returns nil,
+// letting crypto/tls pick its default list.
+func parseCipherSuites(s string) ([]uint16, error) {
+	if s == "" {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	names := strings.Split(s, ",")
+	suites := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite '%s'", name)
+		}
+		suites = append(suites, id)
+	}
+	return suites, nil
+}
+
+// parseALPN converts a comma-separated list of application protocols,
+// e.g. "h2,http/1.1", into a slice. An empty string returns nil.
+func parseALPN(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
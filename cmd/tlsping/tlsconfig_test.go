@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/tls"
+	"reflect"
+	"testing"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    uint16
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"1.0", tls.VersionTLS10, false},
+		{"1.1", tls.VersionTLS11, false},
+		{"1.2", tls.VersionTLS12, false},
+		{"1.3", tls.VersionTLS13, false},
+		{"1.4", 0, true},
+		{"tls1.2", 0, true},
+	}
+	for _, c := range cases {
+		got, err := parseTLSVersion(c.in)
+		if (err != nil) != c.wantErr {
+			t.Errorf("parseTLSVersion(%q) error = %v, wantErr %v", c.in, err, c.wantErr)
+			continue
+		}
+		if err == nil && got != c.want {
+			t.Errorf("parseTLSVersion(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	if suites, err := parseCipherSuites(""); err != nil || suites != nil {
+		t.Errorf("parseCipherSuites(\"\") = %v, %v, want nil, nil", suites, err)
+	}
+
+	name := tls.CipherSuiteName(tls.TLS_RSA_WITH_AES_128_CBC_SHA)
+	suites, err := parseCipherSuites(name)
+	if err != nil {
+		t.Fatalf("parseCipherSuites(%q): %v", name, err)
+	}
+	want := []uint16{tls.TLS_RSA_WITH_AES_128_CBC_SHA}
+	if !reflect.DeepEqual(suites, want) {
+		t.Errorf("parseCipherSuites(%q) = %v, want %v", name, suites, want)
+	}
+
+	if _, err := parseCipherSuites("NOT_A_REAL_CIPHER_SUITE"); err == nil {
+		t.Error("parseCipherSuites with an unknown name should return an error")
+	}
+}
+
+func TestParseALPN(t *testing.T) {
+	if got := parseALPN(""); got != nil {
+		t.Errorf("parseALPN(\"\") = %v, want nil", got)
+	}
+	got := parseALPN("h2,http/1.1")
+	want := []string{"h2", "http/1.1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseALPN(\"h2,http/1.1\") = %v, want %v", got, want)
+	}
+}
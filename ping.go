@@ -2,9 +2,13 @@
 package tlsping
 
 import (
+	"bufio"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"net"
+	"net/http"
 	"sync"
 	"time"
 )
@@ -27,11 +31,118 @@ type Config struct {
 
 	// Number of times to connect. The time spent by every connection will
 	// be measured and the results will be summarized.
+	// In PingStream, Count is an optional cap on the number of connections;
+	// a value of 0 means no cap.
 	Count int
 
+	// Interval between connections when running in streaming mode (see
+	// PingStream). It has no effect on Ping.
+	Interval time.Duration
+
+	// MeasurePhases breaks down the elapsed time of every connection
+	// attempt into its DNS resolution, TCP connect and TLS handshake
+	// phases, reported in PingResult.Phases. It has no effect when
+	// AvoidTLSHandshake is set.
+	MeasurePhases bool
+
+	// SessionResumption controls whether Ping exercises TLS session
+	// resumption. It has no effect when AvoidTLSHandshake is set.
+	SessionResumption SessionResumption
+
+	// Certificates are presented to the server during the TLS handshake,
+	// for mutual TLS authentication. Typically built with
+	// tls.LoadX509KeyPair. It has no effect when AvoidTLSHandshake is set.
+	Certificates []tls.Certificate
+
+	// MinVersion and MaxVersion restrict the range of TLS versions that
+	// may be negotiated, e.g. tls.VersionTLS12. If zero, the crypto/tls
+	// default is used.
+	MinVersion uint16
+	MaxVersion uint16
+
+	// CipherSuites restricts the cipher suites offered during the
+	// handshake. If nil, the crypto/tls default list is used. It has no
+	// effect on TLS 1.3, whose cipher suites are not configurable.
+	CipherSuites []uint16
+
+	// NextProtos is the list of application protocols negotiated via
+	// ALPN, e.g. []string{"h2", "http/1.1"}. If nil, ALPN is not
+	// negotiated.
+	NextProtos []string
+
+	// ServerNameOverride overrides the SNI server name sent during the
+	// handshake and the name used to verify the server certificate. If
+	// empty, the hostname parsed from addr is used.
+	ServerNameOverride string
+
+	// HTTPProbe, when enabled, issues a single HTTP request over every
+	// established connection and measures the time to the first
+	// response byte, in addition to the handshake. It has no effect
+	// when AvoidTLSHandshake is set.
+	HTTPProbe HTTPProbe
+
+	// CAWatcher, when set, overrides RootCAs for every dial performed by
+	// PingStream: its current pool is read immediately before each dial,
+	// so a CA bundle rotated on disk takes effect without restarting a
+	// long-running stream. It has no effect on Ping.
+	CAWatcher *CAWatcher
+
 	Ip string
 }
 
+// HTTPProbe configures an optional HTTP request issued over an
+// established TLS connection, to measure time-to-first-response-byte in
+// addition to the handshake. Only http/1.1 request/response framing is
+// supported; if the connection negotiates "h2" via ALPN, the probe fails
+// with an error rather than attempting h2 framing.
+type HTTPProbe struct {
+	// Enabled turns on the HTTP probe. If false, the other fields are
+	// ignored.
+	Enabled bool
+
+	// Method is the HTTP method to use. Defaults to "GET".
+	Method string
+
+	// Path is the request path. Defaults to "/".
+	Path string
+
+	// Host overrides the Host header sent with the request. Defaults to
+	// the TLS server name.
+	Host string
+
+	// Header holds extra request headers.
+	Header http.Header
+}
+
+// SessionResumption controls how Ping exercises TLS session resumption.
+type SessionResumption int
+
+const (
+	// ResumptionOff performs a plain handshake for every connection,
+	// without a session cache.
+	ResumptionOff SessionResumption = iota
+
+	// ResumptionReuse attaches a session cache, shared across all of
+	// Config.Count connections, so that every connection after the
+	// first attempts to resume the session established by it.
+	ResumptionReuse
+
+	// ResumptionCompare runs Config.Count full handshakes, each with its
+	// own fresh session cache, followed by Config.Count resumed
+	// handshakes against a cache warmed by a prior handshake. Use
+	// CompareResumption, not Ping, to run this mode.
+	ResumptionCompare
+)
+
+// serverName returns the SNI server name to use for the handshake:
+// config.ServerNameOverride if set, otherwise host.
+func serverName(host string, config *Config) string {
+	if config.ServerNameOverride != "" {
+		return config.ServerNameOverride
+	}
+	return host
+}
+
 // Ping establishes network connections to the specified network addr
 // and returns summary statistics of the time spent establishing those
 // connections. The operation is governed by the provided configuration.
@@ -45,7 +156,9 @@ func Ping(addr string, config *Config) (PingResult, error) {
 	if config.Count == 0 {
 		config.Count = 1
 	}
+	dnsStart := time.Now()
 	host, ipAddr, port, err := resolveAddr(addr)
+	dnsSeconds := time.Since(dnsStart).Seconds()
 	if err != nil {
 		return PingResult{}, err
 	}
@@ -55,81 +168,615 @@ func Ping(addr string, config *Config) (PingResult, error) {
 		Address: addr,
 	}
 	target := net.JoinHostPort(ipAddr, port)
-	var f func() error
 	d := &net.Dialer{
 		Timeout: 5 * time.Second,
 	}
+	if config.MeasurePhases && !config.AvoidTLSHandshake {
+		tlsConfig := tls.Config{
+			ServerName:         serverName(host, config),
+			InsecureSkipVerify: config.InsecureSkipVerify,
+			RootCAs:            config.RootCAs,
+			Certificates:       config.Certificates,
+			MinVersion:         config.MinVersion,
+			MaxVersion:         config.MaxVersion,
+			CipherSuites:       config.CipherSuites,
+			NextProtos:         config.NextProtos,
+		}
+		if config.SessionResumption == ResumptionReuse {
+			tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+		}
+		return pingPhases(result, config, d, target, &tlsConfig, dnsSeconds)
+	}
+	var f func() (connInfo, error)
 	if config.AvoidTLSHandshake {
-		f = func() error {
+		f = func() (connInfo, error) {
 			conn, err := d.Dial("tcp", target)
 			if err == nil {
 				conn.Close()
 			}
-			return err
+			return connInfo{}, err
 		}
 	} else {
 		tlsConfig := tls.Config{
-			ServerName:         host,
+			ServerName:         serverName(host, config),
 			InsecureSkipVerify: config.InsecureSkipVerify,
 			RootCAs:            config.RootCAs,
+			Certificates:       config.Certificates,
+			MinVersion:         config.MinVersion,
+			MaxVersion:         config.MaxVersion,
+			CipherSuites:       config.CipherSuites,
+			NextProtos:         config.NextProtos,
+		}
+		if config.SessionResumption == ResumptionReuse {
+			tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
 		}
-		f = func() error {
+		f = func() (connInfo, error) {
 			conn, err := tls.DialWithDialer(d, "tcp", target, &tlsConfig)
-			if err == nil {
-				conn.Close()
+			if err != nil {
+				return connInfo{}, err
+			}
+			defer conn.Close()
+			state := conn.ConnectionState()
+			info := connInfo{
+				resumed: state.DidResume,
+				version: tlsVersionName(state.Version),
+				cipher:  tls.CipherSuiteName(state.CipherSuite),
+				proto:   state.NegotiatedProtocol,
+			}
+			if config.HTTPProbe.Enabled {
+				if info.proto == "h2" {
+					return connInfo{}, fmt.Errorf("HTTP probe does not support h2 framing, only http/1.1")
+				}
+				status, ttfb, err := probeHTTP(conn, tlsConfig.ServerName, config.HTTPProbe)
+				if err != nil {
+					return connInfo{}, err
+				}
+				info.httpStatus = status
+				info.ttfb = ttfb
 			}
-			return err
+			return info, nil
 		}
 	}
 
-	// Launch workers to perform the timing
+	// Launch workers to perform the timing. Resumption can only kick in
+	// once an earlier handshake has completed and cached a session
+	// ticket, so when SessionResumption is ResumptionReuse the dials are
+	// serialized instead of fanned out in parallel; otherwise a later
+	// connection races the first one instead of reusing its ticket.
 	results := make(chan connectDuration, config.Count)
-	var wg sync.WaitGroup
-	wg.Add(config.Count)
-	for i := 0; i < config.Count; i++ {
+	if config.SessionResumption == ResumptionReuse {
 		go func() {
-			defer wg.Done()
-			d, err := timeit(f)
-			results <- connectDuration{
-				seconds: d,
-				err:     err,
+			defer close(results)
+			for i := 0; i < config.Count; i++ {
+				start := time.Now()
+				info, err := f()
+				results <- connectDuration{
+					seconds:  time.Since(start).Seconds(),
+					connInfo: info,
+					err:      err,
+				}
 			}
 		}()
-	}
+	} else {
+		var wg sync.WaitGroup
+		wg.Add(config.Count)
+		for i := 0; i < config.Count; i++ {
+			go func() {
+				defer wg.Done()
+				start := time.Now()
+				info, err := f()
+				results <- connectDuration{
+					seconds:  time.Since(start).Seconds(),
+					connInfo: info,
+					err:      err,
+				}
+			}()
+		}
 
-	// Wait for workers to finish
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+		// Wait for workers to finish
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+	}
 
 	// Collect workers' results
 	durations := make([]float64, 0, config.Count)
+	ttfbDurations := make([]float64, 0, config.Count)
+	resumedCount := 0
 	for res := range results {
 		if res.err != nil {
 			return result, res.err
 		}
 		durations = append(durations, res.seconds)
+		if res.resumed {
+			resumedCount++
+		}
+		result.NegotiatedVersion = res.version
+		result.NegotiatedCipher = res.cipher
+		result.NegotiatedProto = res.proto
+		if config.HTTPProbe.Enabled {
+			ttfbDurations = append(ttfbDurations, res.ttfb)
+			result.HTTPStatus = res.httpStatus
+		}
 	}
 	result.setSummaryStats(summarize(durations))
+	result.Resumed = resumedCount > 0
+	if config.HTTPProbe.Enabled {
+		ttfbStats := summarize(ttfbDurations)
+		result.TTFB = &PhaseStats{Min: ttfbStats.Min, Avg: ttfbStats.Avg, Max: ttfbStats.Max, Std: ttfbStats.Std}
+	}
 	return result, nil
 }
 
+// CompareResumption runs config.Count full TLS handshakes, each with its
+// own fresh session cache, followed by config.Count resumed handshakes
+// against a cache warmed by an initial handshake. It returns summary
+// statistics for both sets of connections plus the mean speedup of a
+// resumed handshake over a full one. addr is of the form 'hostname:port'.
+func CompareResumption(addr string, config *Config) (full PingResult, resumed PingResult, speedup float64, err error) {
+	if config.Count == 0 {
+		config.Count = 1
+	}
+	host, ipAddr, port, err := resolveAddr(addr)
+	if err != nil {
+		return PingResult{}, PingResult{}, 0, err
+	}
+	target := net.JoinHostPort(ipAddr, port)
+	d := &net.Dialer{Timeout: 5 * time.Second}
+
+	fullDurations := make([]float64, 0, config.Count)
+	for i := 0; i < config.Count; i++ {
+		tlsConfig := tls.Config{
+			ServerName:         serverName(host, config),
+			InsecureSkipVerify: config.InsecureSkipVerify,
+			RootCAs:            config.RootCAs,
+			Certificates:       config.Certificates,
+			MinVersion:         config.MinVersion,
+			MaxVersion:         config.MaxVersion,
+			CipherSuites:       config.CipherSuites,
+			NextProtos:         config.NextProtos,
+			ClientSessionCache: tls.NewLRUClientSessionCache(0),
+		}
+		start := time.Now()
+		conn, dialErr := tls.DialWithDialer(d, "tcp", target, &tlsConfig)
+		if dialErr != nil {
+			return PingResult{}, PingResult{}, 0, dialErr
+		}
+		fullDurations = append(fullDurations, time.Since(start).Seconds())
+		conn.Close()
+	}
+
+	warmConfig := tls.Config{
+		ServerName:         serverName(host, config),
+		InsecureSkipVerify: config.InsecureSkipVerify,
+		RootCAs:            config.RootCAs,
+		Certificates:       config.Certificates,
+		MinVersion:         config.MinVersion,
+		MaxVersion:         config.MaxVersion,
+		CipherSuites:       config.CipherSuites,
+		NextProtos:         config.NextProtos,
+		ClientSessionCache: tls.NewLRUClientSessionCache(0),
+	}
+	warmConn, err := tls.DialWithDialer(d, "tcp", target, &warmConfig)
+	if err != nil {
+		return PingResult{}, PingResult{}, 0, err
+	}
+	warmConn.Close()
+
+	resumedDurations := make([]float64, 0, config.Count)
+	for i := 0; i < config.Count; i++ {
+		start := time.Now()
+		conn, dialErr := tls.DialWithDialer(d, "tcp", target, &warmConfig)
+		if dialErr != nil {
+			return PingResult{}, PingResult{}, 0, dialErr
+		}
+		resumedDurations = append(resumedDurations, time.Since(start).Seconds())
+		conn.Close()
+	}
+
+	full = PingResult{Host: host, IPAddr: ipAddr, Address: addr}
+	full.setSummaryStats(summarize(fullDurations))
+	resumed = PingResult{Host: host, IPAddr: ipAddr, Address: addr, Resumed: true}
+	resumed.setSummaryStats(summarize(resumedDurations))
+	return full, resumed, speedupFactor(full.Avg, resumed.Avg), nil
+}
+
+// speedupFactor returns how many times faster a resumed handshake was
+// than a full one, fullAvg / resumedAvg. It returns 0 when resumedAvg is
+// not positive, to avoid dividing by zero or reporting infinite speedup.
+func speedupFactor(fullAvg, resumedAvg float64) float64 {
+	if resumedAvg <= 0 {
+		return 0
+	}
+	return fullAvg / resumedAvg
+}
+
 type connectDuration struct {
 	seconds float64
-	err     error
+	connInfo
+	err error
+}
+
+// connInfo carries the negotiated TLS parameters of a single connection
+// attempt.
+type connInfo struct {
+	resumed    bool
+	version    string
+	cipher     string
+	proto      string
+	httpStatus int
+	ttfb       float64
 }
 
-// timeit measures the time spent executing the argument function f
-// It returns the elapsed time spent as a floating point number of seconds
-func timeit(f func() error) (float64, error) {
+// probeHTTP issues a single HTTP request over conn and measures the time
+// to the first response byte. serverName is used as the Host header and
+// as the fallback TLS server name when probe.Host is empty.
+func probeHTTP(conn net.Conn, serverName string, probe HTTPProbe) (int, float64, error) {
+	method := probe.Method
+	if method == "" {
+		method = "GET"
+	}
+	path := probe.Path
+	if path == "" {
+		path = "/"
+	}
+	host := probe.Host
+	if host == "" {
+		host = serverName
+	}
+	req, err := http.NewRequest(method, "https://"+host+path, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	for name, values := range probe.Header {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	req.Close = true
+
 	start := time.Now()
-	err := f()
-	end := time.Now()
+	if err := req.Write(conn); err != nil {
+		return 0, 0, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	ttfb := time.Since(start).Seconds()
 	if err != nil {
-		return 0, err
+		return 0, ttfb, err
 	}
-	return end.Sub(start).Seconds(), nil
+	resp.Body.Close()
+	return resp.StatusCode, ttfb, nil
+}
+
+// PhaseStats holds aggregate statistics (min/avg/max/stddev) for the time
+// spent in one phase of a connection attempt.
+type PhaseStats struct {
+	Min float64
+	Avg float64
+	Max float64
+	Std float64
+}
+
+// ConnectionPhases breaks down the time spent in a connection attempt
+// into its DNS resolution, TCP connect and TLS handshake phases. It is
+// only populated when Config.MeasurePhases is set.
+type ConnectionPhases struct {
+	DNS       PhaseStats
+	TCP       PhaseStats
+	Handshake PhaseStats
+}
+
+// phaseDuration is the per-phase outcome of a single connection attempt
+// made by pingPhases.
+type phaseDuration struct {
+	total     float64
+	tcp       float64
+	handshake float64
+	connInfo
+	err error
+}
+
+// pingPhases is like Ping, but instead of timing each connection attempt
+// as a whole, it times the TCP connect and TLS handshake phases
+// separately, using a manual net.Dialer.DialContext followed by
+// tls.Client(conn, tlsConfig).HandshakeContext rather than
+// tls.DialWithDialer. dnsSeconds is the time the caller already spent
+// resolving addr.
+func pingPhases(result PingResult, config *Config, d *net.Dialer, target string, tlsConfig *tls.Config, dnsSeconds float64) (PingResult, error) {
+	// As in Ping, resumption only kicks in once an earlier handshake has
+	// cached a session ticket, so dials are serialized rather than
+	// fanned out in parallel when SessionResumption is ResumptionReuse.
+	results := make(chan phaseDuration, config.Count)
+	if config.SessionResumption == ResumptionReuse {
+		go func() {
+			defer close(results)
+			for i := 0; i < config.Count; i++ {
+				results <- dialPhases(d, target, tlsConfig, config.HTTPProbe)
+			}
+		}()
+	} else {
+		var wg sync.WaitGroup
+		wg.Add(config.Count)
+		for i := 0; i < config.Count; i++ {
+			go func() {
+				defer wg.Done()
+				results <- dialPhases(d, target, tlsConfig, config.HTTPProbe)
+			}()
+		}
+
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+	}
+
+	durations := make([]float64, 0, config.Count)
+	tcpDurations := make([]float64, 0, config.Count)
+	handshakeDurations := make([]float64, 0, config.Count)
+	ttfbDurations := make([]float64, 0, config.Count)
+	resumedCount := 0
+	for res := range results {
+		if res.err != nil {
+			return result, res.err
+		}
+		durations = append(durations, res.total)
+		tcpDurations = append(tcpDurations, res.tcp)
+		handshakeDurations = append(handshakeDurations, res.handshake)
+		if res.resumed {
+			resumedCount++
+		}
+		result.NegotiatedVersion = res.version
+		result.NegotiatedCipher = res.cipher
+		result.NegotiatedProto = res.proto
+		if config.HTTPProbe.Enabled {
+			ttfbDurations = append(ttfbDurations, res.ttfb)
+			result.HTTPStatus = res.httpStatus
+		}
+	}
+	result.setSummaryStats(summarize(durations))
+	result.Resumed = resumedCount > 0
+	if config.HTTPProbe.Enabled {
+		ttfbStats := summarize(ttfbDurations)
+		result.TTFB = &PhaseStats{Min: ttfbStats.Min, Avg: ttfbStats.Avg, Max: ttfbStats.Max, Std: ttfbStats.Std}
+	}
+
+	tcpStats := summarize(tcpDurations)
+	handshakeStats := summarize(handshakeDurations)
+	result.Phases = &ConnectionPhases{
+		DNS:       PhaseStats{Min: dnsSeconds, Avg: dnsSeconds, Max: dnsSeconds},
+		TCP:       PhaseStats{Min: tcpStats.Min, Avg: tcpStats.Avg, Max: tcpStats.Max, Std: tcpStats.Std},
+		Handshake: PhaseStats{Min: handshakeStats.Min, Avg: handshakeStats.Avg, Max: handshakeStats.Max, Std: handshakeStats.Std},
+	}
+	return result, nil
+}
+
+// dialPhases performs a single connection attempt, timing the TCP
+// connect and TLS handshake phases separately, and records the
+// negotiated TLS parameters and, if probe is enabled, the HTTP probe
+// outcome.
+func dialPhases(d *net.Dialer, target string, tlsConfig *tls.Config, probe HTTPProbe) phaseDuration {
+	ctx := context.Background()
+	tcpStart := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", target)
+	tcpSeconds := time.Since(tcpStart).Seconds()
+	if err != nil {
+		return phaseDuration{err: err}
+	}
+	tlsConn := tls.Client(conn, tlsConfig)
+	tlsStart := time.Now()
+	err = tlsConn.HandshakeContext(ctx)
+	handshakeSeconds := time.Since(tlsStart).Seconds()
+	if err != nil {
+		tlsConn.Close()
+		return phaseDuration{err: err}
+	}
+	defer tlsConn.Close()
+	state := tlsConn.ConnectionState()
+	info := connInfo{
+		resumed: state.DidResume,
+		version: tlsVersionName(state.Version),
+		cipher:  tls.CipherSuiteName(state.CipherSuite),
+		proto:   state.NegotiatedProtocol,
+	}
+	if probe.Enabled {
+		if info.proto == "h2" {
+			return phaseDuration{err: fmt.Errorf("HTTP probe does not support h2 framing, only http/1.1")}
+		}
+		status, ttfb, err := probeHTTP(tlsConn, tlsConfig.ServerName, probe)
+		if err != nil {
+			return phaseDuration{err: err}
+		}
+		info.httpStatus = status
+		info.ttfb = ttfb
+	}
+	return phaseDuration{
+		total:     tcpSeconds + handshakeSeconds,
+		tcp:       tcpSeconds,
+		handshake: handshakeSeconds,
+		connInfo:  info,
+	}
+}
+
+// PingSample is the outcome of a single connection attempt performed by
+// PingStream.
+type PingSample struct {
+	// Seq is the sequence number of this attempt, starting at 1.
+	Seq int
+
+	// IPAddr is the IP address the connection was made to.
+	IPAddr string
+
+	// Elapsed is the time spent establishing the connection, in seconds.
+	Elapsed float64
+
+	// TLSVersion is the negotiated TLS version, e.g. "TLS 1.3". It is
+	// empty when AvoidTLSHandshake is set.
+	TLSVersion string
+
+	// CipherSuite is the negotiated cipher suite. It is empty when
+	// AvoidTLSHandshake is set.
+	CipherSuite string
+
+	// NegotiatedProto is the application protocol negotiated via ALPN,
+	// e.g. "h2". It is empty when AvoidTLSHandshake is set or ALPN was
+	// not negotiated.
+	NegotiatedProto string
+
+	// Resumed reports whether this connection resumed a previous TLS
+	// session. It is always false when AvoidTLSHandshake is set.
+	Resumed bool
+
+	// HTTPStatus and TTFB are set when Config.HTTPProbe is enabled:
+	// HTTPStatus is the response status code and TTFB is the time to the
+	// first response byte, in seconds.
+	HTTPStatus int
+	TTFB       float64
+
+	// Err is set when the connection attempt failed.
+	Err error
+}
+
+// PingStream behaves like Ping, but instead of dialing config.Count
+// connections in parallel and summarizing them, it opens one connection
+// every config.Interval and sends one PingSample per attempt on the
+// returned channel. If config.Count is greater than zero, the stream
+// stops after that many attempts; otherwise it keeps running until the
+// returned stop function is called. Calling stop also closes the
+// channel, so callers should keep draining it until it is closed.
+// addr is of the form 'hostname:port'.
+func PingStream(addr string, config *Config) (<-chan PingSample, func(), error) {
+	host, ipAddr, port, err := resolveAddr(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	target := net.JoinHostPort(ipAddr, port)
+	d := &net.Dialer{
+		Timeout: 5 * time.Second,
+	}
+	tlsConfig := tls.Config{
+		ServerName:         serverName(host, config),
+		InsecureSkipVerify: config.InsecureSkipVerify,
+		RootCAs:            config.RootCAs,
+		Certificates:       config.Certificates,
+		MinVersion:         config.MinVersion,
+		MaxVersion:         config.MaxVersion,
+		CipherSuites:       config.CipherSuites,
+		NextProtos:         config.NextProtos,
+	}
+	if config.SessionResumption == ResumptionReuse {
+		tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+
+	samples := make(chan PingSample)
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() { close(done) })
+	}
+
+	if config.CAWatcher != nil {
+		go config.CAWatcher.Run(done)
+	}
+
+	go func() {
+		defer close(samples)
+		interval := config.Interval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for seq := 1; ; seq++ {
+			if config.CAWatcher != nil {
+				tlsConfig.RootCAs = config.CAWatcher.Pool()
+			}
+			sample := dialOnce(seq, d, target, &tlsConfig, config.AvoidTLSHandshake, config.HTTPProbe)
+			select {
+			case samples <- sample:
+			case <-done:
+				return
+			}
+			if config.Count > 0 && seq >= config.Count {
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return samples, stop, nil
+}
+
+// dialOnce performs a single connection attempt and times it, recording
+// TLS details when the handshake is performed.
+func dialOnce(seq int, d *net.Dialer, target string, tlsConfig *tls.Config, tcpOnly bool, probe HTTPProbe) PingSample {
+	sample := PingSample{Seq: seq}
+	start := time.Now()
+	if tcpOnly {
+		conn, err := d.Dial("tcp", target)
+		sample.Elapsed = time.Since(start).Seconds()
+		sample.Err = err
+		if err == nil {
+			sample.IPAddr = conn.RemoteAddr().(*net.TCPAddr).IP.String()
+			conn.Close()
+		}
+		return sample
+	}
+	conn, err := tls.DialWithDialer(d, "tcp", target, tlsConfig)
+	sample.Elapsed = time.Since(start).Seconds()
+	sample.Err = err
+	if err != nil {
+		return sample
+	}
+	defer conn.Close()
+	sample.IPAddr = conn.RemoteAddr().(*net.TCPAddr).IP.String()
+	state := conn.ConnectionState()
+	sample.TLSVersion = tlsVersionName(state.Version)
+	sample.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	sample.NegotiatedProto = state.NegotiatedProtocol
+	sample.Resumed = state.DidResume
+	if probe.Enabled {
+		if sample.NegotiatedProto == "h2" {
+			sample.Err = fmt.Errorf("HTTP probe does not support h2 framing, only http/1.1")
+			return sample
+		}
+		status, ttfb, err := probeHTTP(conn, tlsConfig.ServerName, probe)
+		sample.HTTPStatus = status
+		sample.TTFB = ttfb
+		sample.Err = err
+	}
+	return sample
+}
+
+// tlsVersionName returns a human readable name for a TLS version
+// constant from the crypto/tls package.
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return "unknown"
+	}
+}
+
+// NewSummary aggregates a set of elapsed-time samples, expressed in
+// seconds, into a PingResult carrying only the summary statistics. It is
+// used to summarize the samples collected via PingStream, the same way
+// Ping summarizes its own connection attempts.
+func NewSummary(durations []float64) PingResult {
+	var result PingResult
+	result.setSummaryStats(summarize(durations))
+	return result
 }
 
 // resolveAddr queries the DNS to resolve the name of the host
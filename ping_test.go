@@ -0,0 +1,26 @@
+package tlsping
+
+import "testing"
+
+func TestSpeedupFactor(t *testing.T) {
+	cases := []struct {
+		name      string
+		fullAvg   float64
+		resumeAvg float64
+		want      float64
+	}{
+		{"resumed twice as fast", 0.200, 0.100, 2},
+		{"no speedup", 0.150, 0.150, 1},
+		{"resumed slower", 0.100, 0.200, 0.5},
+		{"resumed avg zero", 0.200, 0, 0},
+		{"resumed avg negative", 0.200, -1, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := speedupFactor(c.fullAvg, c.resumeAvg)
+			if got != c.want {
+				t.Errorf("speedupFactor(%v, %v) = %v, want %v", c.fullAvg, c.resumeAvg, got, c.want)
+			}
+		})
+	}
+}